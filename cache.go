@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileCache remembers, per combination of file path and the updates applied
+// to it, the resulting file hash from the last run. It lets updateHclFile
+// skip a file entirely when it still hashes to the value that run last
+// produced, similar to how the Go build cache skips work whose inputs
+// haven't changed.
+type fileCache struct {
+	path    string
+	entries map[string]string
+	dirty   bool
+}
+
+// loadFileCache loads the on-disk cache from $XDG_CACHE_HOME/bump-tf-values
+// (falling back to ~/.cache/bump-tf-values), or returns an empty cache if
+// none exists yet or the existing one can't be read.
+func loadFileCache() (*fileCache, error) {
+	path, err := cacheFilePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine cache path: %w", err)
+	}
+
+	entries := map[string]string{}
+	if data, err := os.ReadFile(path); err == nil {
+		// a corrupt cache file is not fatal: fall back to an empty cache
+		_ = json.Unmarshal(data, &entries)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	return &fileCache{path: path, entries: entries}, nil
+}
+
+// cacheFilePath returns $XDG_CACHE_HOME/bump-tf-values/cache.json, falling
+// back to ~/.cache/bump-tf-values/cache.json if XDG_CACHE_HOME is unset.
+func cacheFilePath() (string, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine home directory: %w", err)
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(base, "bump-tf-values", "cache.json"), nil
+}
+
+// matches reports whether key is cached as having last produced a file that
+// hashes to currentHash, meaning the file is already at its desired state
+// and can be skipped.
+func (c *fileCache) matches(key, currentHash string) bool {
+	cached, ok := c.entries[key]
+	return ok && cached == currentHash
+}
+
+// record stores the hash a file produced for key, to be checked on a future
+// run.
+func (c *fileCache) record(key, resultHash string) {
+	if c.entries[key] == resultHash {
+		return
+	}
+	c.entries[key] = resultHash
+	c.dirty = true
+}
+
+// save persists the cache to disk, if anything changed since it was loaded.
+func (c *fileCache) save() error {
+	if !c.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	return nil
+}
+
+// cacheKey builds the cache key for filePath, kind, and a batch of updates,
+// as sha256(filepath + kind + varname + value) for each update, joined and
+// hashed together so the key changes if any update's name or value does.
+// kind is included so that, e.g., bumping local.code_version and
+// variable.code_version to the same value don't collide on the same key.
+func cacheKey(filePath string, kind targetKind, updates []varAssignment) string {
+	parts := make([]string, 0, len(updates))
+	for _, update := range updates {
+		parts = append(parts, fmt.Sprintf("%s%s%s%s", filePath, kind, update.Name, compactCtyValue(update.Value)))
+	}
+	sort.Strings(parts)
+	return hashString(strings.Join(parts, "\x00"))
+}
+
+// hashFileContent returns the hex-encoded sha256 of content.
+func hashFileContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFileCacheLoadSaveRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cache, err := loadFileCache()
+	assert.NoError(t, err)
+	assert.False(t, cache.matches("key", "hash"))
+
+	cache.record("key", "hash")
+	assert.NoError(t, cache.save())
+
+	reloaded, err := loadFileCache()
+	assert.NoError(t, err)
+	assert.True(t, reloaded.matches("key", "hash"))
+}
+
+func TestFileCacheRecordOnlyMarksDirtyOnChange(t *testing.T) {
+	cache := &fileCache{entries: map[string]string{}}
+	cache.record("key", "hash")
+	assert.True(t, cache.dirty)
+
+	cache.dirty = false
+	cache.record("key", "hash")
+	assert.False(t, cache.dirty, "recording the same hash again should not mark the cache dirty")
+}
+
+func TestCacheKeyChangesWithUpdates(t *testing.T) {
+	a := []varAssignment{{Name: "code_version", Value: cty.StringVal("1.1.1.1")}}
+	b := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+
+	assert.NotEqual(t, cacheKey("test.tf", targetKindLocal, a), cacheKey("test.tf", targetKindLocal, b))
+	assert.Equal(t, cacheKey("test.tf", targetKindLocal, a), cacheKey("test.tf", targetKindLocal, a))
+	assert.NotEqual(t, cacheKey("a.tf", targetKindLocal, a), cacheKey("b.tf", targetKindLocal, a))
+}
+
+func TestCacheKeyChangesWithTargetKind(t *testing.T) {
+	updates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+
+	assert.NotEqual(
+		t,
+		cacheKey("test.tf", targetKindLocal, updates),
+		cacheKey("test.tf", targetKindVariable, updates),
+		"bumping local.code_version and variable.code_version to the same value must not collide on the same cache key",
+	)
+}
+
+func TestHasComputedUpdate(t *testing.T) {
+	assert.False(t, hasComputedUpdate([]varAssignment{{Name: "code_version", Value: cty.StringVal("1.1.1.1")}}))
+	assert.True(t, hasComputedUpdate([]varAssignment{{Name: "code_version", Compute: makeBumpCompute(bumpModePatch, "")}}))
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Change records a single staged edit made through a Fixer, along with the
+// source range it replaced. It exists mainly for --dry-run reporting.
+type Change struct {
+	Range   hcl.Range
+	Summary string
+}
+
+// Fixer wraps an in-memory hclwrite.File and tracks the edits applied to it,
+// similar in spirit to tflint-plugin-sdk's autofix Fixer. Update functions
+// (updateLocal and friends) stage their mutations through a Fixer instead of
+// writing to the hclwrite.Body directly, which gives callers a uniform way
+// to inspect, diff, or roll back a batch of edits before anything touches
+// disk.
+type Fixer struct {
+	filename string
+	original []byte
+	file     *hclwrite.File
+	changes  []Change
+	stash    []byte
+}
+
+// NewFixer parses content and returns a Fixer ready to accept staged edits.
+func NewFixer(filename string, content []byte) (*Fixer, error) {
+	file, diags := hclwrite.ParseConfig(content, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse file content: %s", diags)
+	}
+
+	return &Fixer{
+		filename: filename,
+		original: append([]byte(nil), content...),
+		file:     file,
+	}, nil
+}
+
+// File returns the underlying hclwrite.File so callers can walk its blocks.
+func (f *Fixer) File() *hclwrite.File {
+	return f.file
+}
+
+// Changed reports whether any edits have been staged.
+func (f *Fixer) Changed() bool {
+	return len(f.changes) > 0
+}
+
+// Changes returns the edits staged so far, in the order they were applied.
+func (f *Fixer) Changes() []Change {
+	return f.changes
+}
+
+// Bytes renders the file in its current state, including any staged edits.
+func (f *Fixer) Bytes() []byte {
+	return f.file.Bytes()
+}
+
+// SetAttributeValue stages setting name to value within body, recording the
+// source range it replaces so the edit can be reported or diffed later.
+func (f *Fixer) SetAttributeValue(body *hclwrite.Body, sourceRange hcl.Range, name string, value cty.Value) {
+	f.changes = append(f.changes, Change{
+		Range:   sourceRange,
+		Summary: fmt.Sprintf("set %s = %s", name, compactCtyValue(value)),
+	})
+	body.SetAttributeValue(name, value)
+}
+
+// blockSpec identifies a block by type and labels, e.g. {"module", []string{"y"}}
+// or {"required_providers", nil} for an unlabelled block.
+type blockSpec struct {
+	Type   string
+	Labels []string
+}
+
+// AttributeRange locates the source range of name's value within the
+// original (pre-edit) content, following path down through nested blocks
+// (e.g. terraform -> required_providers). hclwrite does not retain source
+// positions, so this re-parses the original bytes with hclsyntax, which
+// does.
+func (f *Fixer) AttributeRange(path []blockSpec, name string) (hcl.Range, bool) {
+	attr, ok := f.originalAttribute(path, name)
+	if !ok {
+		return hcl.Range{}, false
+	}
+	return attr.Expr.Range(), true
+}
+
+// AttributeValue evaluates name's current value within the original
+// (pre-edit) content, following path down through nested blocks.
+func (f *Fixer) AttributeValue(path []blockSpec, name string) (cty.Value, bool) {
+	attr, ok := f.originalAttribute(path, name)
+	if !ok {
+		return cty.NilVal, false
+	}
+
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal, false
+	}
+
+	return value, true
+}
+
+func (f *Fixer) originalAttribute(path []blockSpec, name string) (*hclsyntax.Attribute, bool) {
+	parsed, diags := hclsyntax.ParseConfig(f.original, f.filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	body, ok := parsed.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+
+	for _, spec := range path {
+		block := findHclsyntaxBlock(body, spec.Type, spec.Labels)
+		if block == nil {
+			return nil, false
+		}
+		body = block.Body
+	}
+
+	attr, ok := body.Attributes[name]
+	return attr, ok
+}
+
+func findHclsyntaxBlock(body *hclsyntax.Body, blockType string, labels []string) *hclsyntax.Block {
+	for _, block := range body.Blocks {
+		if block.Type == blockType && labelsMatch(block.Labels, labels) {
+			return block
+		}
+	}
+	return nil
+}
+
+// Diff returns a unified diff between the original content and the staged
+// edits, suitable for printing under --dry-run.
+func (f *Fixer) Diff() (string, error) {
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(f.original)),
+		B:        difflib.SplitLines(string(f.Bytes())),
+		FromFile: f.filename,
+		ToFile:   f.filename,
+		Context:  3,
+	}
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// StashChanges snapshots the file's current bytes so a later failure can be
+// rolled back to this point with PopChanges.
+func (f *Fixer) StashChanges() {
+	f.stash = append([]byte(nil), f.Bytes()...)
+}
+
+// PopChanges discards all edits staged since the last StashChanges call,
+// restoring the file to its stashed state. It returns an error if no stash
+// has been taken.
+func (f *Fixer) PopChanges() error {
+	if f.stash == nil {
+		return fmt.Errorf("no stashed changes to restore")
+	}
+
+	file, diags := hclwrite.ParseConfig(f.stash, f.filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to restore stashed changes: %s", diags)
+	}
+
+	f.file = file
+	f.changes = nil
+	return nil
+}
+
+func labelsMatch(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func compactCtyValue(v cty.Value) string {
+	if v.IsNull() {
+		return "null"
+	}
+	switch {
+	case v.Type() == cty.String:
+		return v.AsString()
+	default:
+		return v.GoString()
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFixerStashAndPopChanges(t *testing.T) {
+	content := "locals {\n  code_version = \"1.1.1.1\"\n}\n"
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	fixer.StashChanges()
+
+	block := fixer.File().Body().Blocks()[0]
+	rng, ok := fixer.AttributeRange([]blockSpec{{Type: "locals"}}, "code_version")
+	assert.True(t, ok)
+	fixer.SetAttributeValue(block.Body(), rng, "code_version", cty.StringVal("2.2.2.2"))
+	assert.True(t, fixer.Changed())
+	assert.Contains(t, string(fixer.Bytes()), `code_version = "2.2.2.2"`)
+
+	assert.NoError(t, fixer.PopChanges())
+	assert.False(t, fixer.Changed())
+	assert.Equal(t, content, string(fixer.Bytes()))
+}
+
+func TestFixerPopChangesWithoutStash(t *testing.T) {
+	fixer, err := NewFixer("test.tf", []byte("locals {\n  code_version = \"1.1.1.1\"\n}\n"))
+	assert.NoError(t, err)
+
+	err = fixer.PopChanges()
+	assert.ErrorContains(t, err, "no stashed changes")
+}
+
+func TestFixerDiff(t *testing.T) {
+	content := "locals {\n  code_version = \"1.1.1.1\"\n}\n"
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	block := fixer.File().Body().Blocks()[0]
+	rng, _ := fixer.AttributeRange([]blockSpec{{Type: "locals"}}, "code_version")
+	fixer.SetAttributeValue(block.Body(), rng, "code_version", cty.StringVal("2.2.2.2"))
+
+	diff, err := fixer.Diff()
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "-  code_version = \"1.1.1.1\"")
+	assert.Contains(t, diff, "+  code_version = \"2.2.2.2\"")
+}
+
+func TestFixerAttributeRangeNotFound(t *testing.T) {
+	fixer, err := NewFixer("test.tf", []byte("locals {\n  code_version = \"1.1.1.1\"\n}\n"))
+	assert.NoError(t, err)
+
+	_, ok := fixer.AttributeRange([]blockSpec{{Type: "locals"}}, "missing")
+	assert.False(t, ok)
+}
@@ -2,18 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
-	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"github.com/zclconf/go-cty/cty"
 )
 
+// errPendingChanges is returned by updateHclFile when --check is set and at
+// least one staged edit would have modified a file.
+var errPendingChanges = errors.New("pending changes detected")
+
+// updateOptions controls how updateHclFile applies the edits it stages.
+type updateOptions struct {
+	// DryRun prints a unified diff of the pending edits instead of writing them.
+	DryRun bool
+	// Check exits with errPendingChanges if any edit would change a file,
+	// without writing anything. Intended for CI gating.
+	Check bool
+}
+
 func main() {
 	// initiate logging
 	logger := log.With().Logger()
@@ -21,8 +33,25 @@ func main() {
 
 	// check for command line flags
 	debug := flag.Bool("debug", false, "set log level to debug")
+	dryRun := flag.Bool("dry-run", false, "print a diff of the pending edits without writing to disk")
+	check := flag.Bool("check", false, "exit non-zero if any change would be made, without writing to disk")
+	targetKindFlag := flag.String("target-kind", string(targetKindLocal), "kind of block to update: local, variable, module, provider, or terraform")
+	varFlags := newVarFlagList()
+	flag.Var(varFlags, "var", "set a variable value as name=value (repeatable)")
+	varFile := flag.String("var-file", "", "path to a JSON or HCL file of name=value overrides")
+	bumpTargets := newStringFlagList()
+	flag.Var(bumpTargets, "bump-target", "address of a target to version-bump, e.g. y.version (repeatable)")
+	bumpMode := flag.String("bump", "", "semver segment to bump -bump-target entries by: patch, minor, or major")
+	prerelease := flag.String("prerelease", "", "prerelease suffix to append to a -bump result, e.g. beta.1")
+	pinFrom := flag.String("pin-from", "", "Terraform Registry base URL to pin -bump-target entries to their highest matching version")
 	flag.Parse()
 
+	kind, err := parseTargetKind(*targetKindFlag)
+	if err != nil {
+		log.Ctx(ctx).Err(err).Msg("invalid target kind")
+		return
+	}
+
 	// set log level
 	level := zerolog.WarnLevel
 	if *debug {
@@ -30,15 +59,37 @@ func main() {
 	}
 	zerolog.SetGlobalLevel(level)
 
-	// load env vars
-	filePath := os.Getenv("INPUT_FILEPATH")
-	varname := os.Getenv("INPUT_VARNAME")
-	value := os.Getenv("INPUT_VALUE")
-	log.Ctx(ctx).Debug().Str("filepath", filePath).Str("varname", varname).Str("value", value).Msg("env vars loaded")
+	// the target file(s) may be given as a glob via the first positional
+	// argument, falling back to the GitHub Action's INPUT_FILEPATH
+	filePattern := os.Getenv("INPUT_FILEPATH")
+	if flag.NArg() > 0 {
+		filePattern = flag.Arg(0)
+	}
+
+	updates, err := collectUpdates(ctx, *varFile, varFlags.values)
+	if err != nil {
+		log.Ctx(ctx).Err(err).Msg("failed to collect variable updates")
+		return
+	}
 
-	// open specified Terraform file
-	err := updateHclFile(ctx, filePath, varname, value)
+	bumpUpdates, err := buildBumpUpdates(bumpTargets.values, *bumpMode, *prerelease, *pinFrom)
 	if err != nil {
+		log.Ctx(ctx).Err(err).Msg("invalid bump configuration")
+		return
+	}
+	updates = append(updates, bumpUpdates...)
+
+	log.Ctx(ctx).Debug().Str("filepath", filePattern).Int("updates", len(updates)).Msg("inputs loaded")
+
+	opts := updateOptions{DryRun: *dryRun, Check: *check}
+
+	// open specified Terraform file(s)
+	err = updateHclFile(ctx, filePattern, kind, updates, opts)
+	if err != nil {
+		if errors.Is(err, errPendingChanges) {
+			log.Ctx(ctx).Error().Msg("pending changes detected")
+			os.Exit(1)
+		}
 		log.Ctx(ctx).Err(err).Msg("failed to update HCL file")
 		return
 	}
@@ -46,8 +97,117 @@ func main() {
 	log.Ctx(ctx).Info().Msg("file updated successfully")
 }
 
-// handles steps required to load, update and save the specified file
-func updateHclFile(ctx context.Context, filePath, varname, value string) error {
+// collectUpdates merges a -var-file with repeated -var flags. When neither is
+// set it falls back to the single INPUT_VARNAME/INPUT_VALUE pair used by the
+// GitHub Action, preserving the tool's original single-variable behaviour.
+func collectUpdates(ctx context.Context, varFilePath string, varFlags []varAssignment) ([]varAssignment, error) {
+	var updates []varAssignment
+
+	if varFilePath != "" {
+		fileVars, err := loadVarFile(varFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load var file: %w", err)
+		}
+		for name, value := range fileVars {
+			updates = append(updates, varAssignment{Name: name, Value: value})
+		}
+	}
+
+	updates = append(updates, varFlags...)
+
+	if len(updates) > 0 {
+		return updates, nil
+	}
+
+	// fall back to the legacy single-variable env var inputs
+	varname := os.Getenv("INPUT_VARNAME")
+	value := os.Getenv("INPUT_VALUE")
+	if varname == "" {
+		return nil, fmt.Errorf("no variables specified: use -var, -var-file, or INPUT_VARNAME/INPUT_VALUE")
+	}
+
+	typed, err := parseTypedValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse INPUT_VALUE: %w", err)
+	}
+
+	return []varAssignment{{Name: varname, Value: typed}}, nil
+}
+
+// buildBumpUpdates turns the -bump-target addresses into varAssignments
+// whose value is computed per file, either by bumping the target's current
+// semver value (-bump) or by pinning it to the highest version satisfying
+// its current constraint according to a module registry (-pin-from).
+func buildBumpUpdates(targets []string, bumpModeRaw, prerelease, pinFromURL string) ([]varAssignment, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	var compute computeFunc
+	switch {
+	case pinFromURL != "":
+		compute = makePinFromCompute(pinFromURL)
+	case bumpModeRaw != "":
+		mode, err := parseBumpMode(bumpModeRaw)
+		if err != nil {
+			return nil, err
+		}
+		compute = makeBumpCompute(mode, prerelease)
+	default:
+		return nil, fmt.Errorf("-bump-target given without -bump or -pin-from")
+	}
+
+	updates := make([]varAssignment, 0, len(targets))
+	for _, target := range targets {
+		updates = append(updates, varAssignment{Name: target, Compute: compute})
+	}
+	return updates, nil
+}
+
+// updateHclFile expands filePattern as a glob and applies every update to
+// each matching file in turn. A file is only rewritten once all of its
+// updates succeed; a failure partway through a file rolls that file's edits
+// back to their pre-run state via the file's Fixer. Files whose content
+// still hashes to the result of a previous run's updates are skipped
+// entirely, via an on-disk cache (see cache.go).
+func updateHclFile(ctx context.Context, filePattern string, kind targetKind, updates []varAssignment, opts updateOptions) error {
+	files, err := filepath.Glob(filePattern)
+	if err != nil {
+		return fmt.Errorf("failed to expand file pattern: %w", err)
+	}
+	if len(files) == 0 {
+		// not a glob, or a glob that matched nothing: treat literally so
+		// that a plain path still produces a familiar "file not found" error
+		files = []string{filePattern}
+	}
+
+	cache, err := loadFileCache()
+	if err != nil {
+		log.Ctx(ctx).Err(err).Msg("failed to load cache, continuing without it")
+		cache = &fileCache{entries: map[string]string{}}
+	}
+
+	for _, filePath := range files {
+		if err := updateHclFileAt(ctx, filePath, kind, updates, opts, cache); err != nil {
+			return fmt.Errorf("failed to update %s: %w", filePath, err)
+		}
+	}
+
+	if err := cache.save(); err != nil {
+		log.Ctx(ctx).Err(err).Msg("failed to save cache")
+	}
+
+	return nil
+}
+
+// updateHclFileAt drives a Fixer through every update for a single file:
+// staged edits are stashed up front so a failing update can be rolled back,
+// then the result is either diffed (--dry-run), checked (--check), or
+// written back to disk. If every update has a static value (none computed
+// via --bump/--pin-from) and the file's current bytes already match cache's
+// record of this update batch's result, the file is skipped without being
+// parsed at all.
+func updateHclFileAt(ctx context.Context, filePath string, kind targetKind, updates []varAssignment, opts updateOptions, cache *fileCache) error {
 	file, err := os.OpenFile(filePath, os.O_RDWR, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %v", err)
@@ -59,41 +219,94 @@ func updateHclFile(ctx context.Context, filePath, varname, value string) error {
 		}
 	}()
 
-	hclFile, err := parseHclFile(ctx, file)
+	content, err := readFileContent(file)
 	if err != nil {
-		return fmt.Errorf("failed to parse HCL file: %v", err)
+		return fmt.Errorf("failed to read file content: %v", err)
 	}
 
-	if err := updateLocal(ctx, hclFile, varname, value); err != nil {
-		return fmt.Errorf("failed to update local: %v", err)
+	cacheable := !hasComputedUpdate(updates)
+	var key string
+	if cacheable {
+		key = cacheKey(filePath, kind, updates)
+		if cache.matches(key, hashFileContent(content)) {
+			log.Ctx(ctx).Debug().Str("file", filePath).Msg("skipping file unchanged since last run")
+			return nil
+		}
 	}
 
-	if err := saveHCLToFile(file, ctx, hclFile); err != nil {
-		return fmt.Errorf("failed to save to file: %v", err)
+	fixer, err := NewFixer(filePath, content)
+	if err != nil {
+		return fmt.Errorf("failed to parse HCL file: %v", err)
 	}
 
-	return nil
-}
+	fixer.StashChanges()
+	for _, update := range updates {
+		value := update.Value
+		if update.Compute != nil {
+			current, _ := resolveTargetValue(fixer, kind, update.Name)
+			computed, err := update.Compute(ctx, fixer, kind, update.Name, current)
+			if err != nil {
+				if popErr := fixer.PopChanges(); popErr != nil {
+					log.Ctx(ctx).Err(popErr).Msg("failed to roll back staged changes")
+				}
+				return fmt.Errorf("failed to compute target value: %v", err)
+			}
+			value = computed
+		}
 
-// saveHCLToFile saves HCL configuration to file.
-func saveHCLToFile(file *os.File, ctx context.Context, hclFile *hclwrite.File) error {
-	if err := file.Truncate(0); err != nil {
-		return fmt.Errorf("failed to truncate file: %w", err)
+		if err := updateTarget(ctx, fixer, kind, update.Name, value); err != nil {
+			if popErr := fixer.PopChanges(); popErr != nil {
+				log.Ctx(ctx).Err(popErr).Msg("failed to roll back staged changes")
+			}
+			return fmt.Errorf("failed to update target: %v", err)
+		}
 	}
 
-	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek the start of file: %w", err)
+	if opts.Check {
+		if fixer.Changed() {
+			return errPendingChanges
+		}
+		if cacheable {
+			cache.record(key, hashFileContent(fixer.Bytes()))
+		}
+		return nil
 	}
 
-	if _, err := hclFile.WriteTo(file); err != nil {
-		return fmt.Errorf("failed to write to file: %w", err)
+	if opts.DryRun {
+		diff, err := fixer.Diff()
+		if err != nil {
+			return fmt.Errorf("failed to build diff: %w", err)
+		}
+		fmt.Print(diff)
+		return nil
+	}
+
+	if err := writeContentToFile(file, fixer.Bytes()); err != nil {
+		return fmt.Errorf("failed to save to file: %v", err)
+	}
+
+	if cacheable {
+		cache.record(key, hashFileContent(fixer.Bytes()))
 	}
 
 	return nil
 }
 
-// parseHclFile reads and parses the content of the file as HCL format
-func parseHclFile(ctx context.Context, file *os.File) (*hclwrite.File, error) {
+// hasComputedUpdate reports whether any update's value is derived per file
+// (--bump, --pin-from) rather than fixed, which makes the batch ineligible
+// for the file-hash cache: the value to check against isn't known until the
+// file has already been read and evaluated.
+func hasComputedUpdate(updates []varAssignment) bool {
+	for _, update := range updates {
+		if update.Compute != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readFileContent reads the full contents of an already-open file.
+func readFileContent(file *os.File) ([]byte, error) {
 	info, err := file.Stat()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file info: %w", err)
@@ -104,31 +317,22 @@ func parseHclFile(ctx context.Context, file *os.File) (*hclwrite.File, error) {
 		return nil, fmt.Errorf("failed to read file content: %w", err)
 	}
 
-	// parse the file content into HCL format
-	hclFile, diags := hclwrite.ParseConfig(content, info.Name(), hcl.Pos{Line: 1, Column: 1})
-	if diags.HasErrors() {
-		return nil, fmt.Errorf("failed to parse file content: %s", diags)
-	}
-
-	return hclFile, nil
+	return content, nil
 }
 
-// find local
-// modify local value in hclfile
-func updateLocal(ctx context.Context, file *hclwrite.File, varname string, value string) error {
-	found := false
-	for _, block := range file.Body().Blocks() {
-		if block.Type() == "locals" {
-			local := block.Body().GetAttribute(varname)
-			if local != nil {
-				found = true
-				block.Body().SetAttributeValue(varname, cty.StringVal(value))
-				break // exit loop once variable is found and updated
-			}
-		}
+// writeContentToFile overwrites file's contents in place.
+func writeContentToFile(file *os.File, content []byte) error {
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate file: %w", err)
 	}
-	if !found {
-		return fmt.Errorf("local variable '%s' not found", varname)
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek the start of file: %w", err)
+	}
+
+	if _, err := file.Write(content); err != nil {
+		return fmt.Errorf("failed to write to file: %w", err)
 	}
+
 	return nil
 }
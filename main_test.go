@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"context"
 	"os"
+	"strings"
 	"testing"
 
-	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
@@ -14,6 +14,14 @@ import (
 )
 
 func TestMain(m *testing.M) {
+	// isolate the file-hash cache (cache.go) from the real user cache dir
+	cacheDir, err := os.MkdirTemp("", "bump-tf-values-cache")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	os.Setenv("XDG_CACHE_HOME", cacheDir)
+
 	// Run tests
 	code := m.Run()
 	os.Exit(code)
@@ -93,7 +101,7 @@ func TestUpdateHclFileWithFileErrorE2E(t *testing.T) {
 	file.Close()
 
 	// test
-	err = updateHclFile(ctx, file.Name(), "code_version", "v2.55.4")
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, []varAssignment{{Name: "code_version", Value: cty.StringVal("v2.55.4")}}, updateOptions{})
 
 	// check if an error was logged
 	assert.ErrorContains(t, err, "failed to parse HCL file", "Expected an error parsing HCL file")
@@ -130,10 +138,10 @@ func TestUpdateHclFileWithLocalErrorE2E(t *testing.T) {
 	file.Close()
 
 	// test
-	err = updateHclFile(ctx, file.Name(), "code_version", "v2.55.4")
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, []varAssignment{{Name: "code_version", Value: cty.StringVal("v2.55.4")}}, updateOptions{})
 
 	// check if an error was logged
-	assert.ErrorContains(t, err, "failed to update local", "Expected an error parsing HCL file")
+	assert.ErrorContains(t, err, "failed to update target", "Expected an error parsing HCL file")
 }
 
 func TestUpdateHclFileWithSaveErrorE2E(t *testing.T) {
@@ -171,133 +179,348 @@ func TestUpdateHclFileWithSaveErrorE2E(t *testing.T) {
 	assert.NoError(t, err, "Unable to set file as readonly")
 
 	// test
-	err = updateHclFile(ctx, file.Name(), "code_version", "v2.55.4")
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, []varAssignment{{Name: "code_version", Value: cty.StringVal("v2.55.4")}}, updateOptions{})
 
 	// check if an error was logged
 	assert.Error(t, err, "Expected an error parsing HCL file")
 }
 
-func TestUpdateLocalNotFound(t *testing.T) {
+func TestUpdateHclFileDryRun(t *testing.T) {
+	file, err := os.CreateTemp("", "testhcl.tf")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
 
-	// Create a logger that writes to a buffer
-	buf := bytes.Buffer{}
-	logger := zerolog.New(&buf).With().Timestamp().Logger()
+	_, err = file.WriteString("locals {\n  code_version = \"1.1.1.1\"\n}\n")
+	assert.NoError(t, err)
+	file.Close()
+
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	updates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{DryRun: true})
+	assert.NoError(t, err)
+
+	// a dry run must never touch the file on disk
+	data, err := os.ReadFile(file.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `code_version = "1.1.1.1"`)
+}
+
+func TestUpdateHclFileCheckModeFailsOnPendingChanges(t *testing.T) {
+	file, err := os.CreateTemp("", "testhcl.tf")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("locals {\n  code_version = \"1.1.1.1\"\n}\n")
+	assert.NoError(t, err)
+	file.Close()
+
+	logger := log.With().Logger()
 	ctx := logger.WithContext(context.Background())
 
-	// create a new HCL file with no locals block
-	file := hclwrite.NewEmptyFile()
+	updates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{Check: true})
+	assert.ErrorIs(t, err, errPendingChanges)
+
+	// --check must never touch the file on disk
+	data, err := os.ReadFile(file.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `code_version = "1.1.1.1"`)
+}
+
+func TestWriteContentToFileWithError(t *testing.T) {
+
+	// Create an invalid file handle (nil pointer) to cause an error
+	var tempFile *os.File
+
+	// Call the function under test with a nil file handle
+	err := writeContentToFile(tempFile, []byte("resource \"aws_s3_bucket\" \"my_bucket\" {}"))
+
+	// Check that an error was returned
+	assert.Error(t, err, "No error returned by function")
+}
+
+func TestReadFileContentNilFile(t *testing.T) {
+
+	// pass in a nil file
+	var tmpFile *os.File
 
-	// call the function under test with a non-existent local variable name
-	err := updateLocal(ctx, file, "my_var", "my_value")
+	// call the function under test
+	content, err := readFileContent(tmpFile)
 
 	// assert that the function returns an error
 	assert.Error(t, err)
+	assert.Nil(t, content)
 
 	// assert that the error message contains the expected substring
-	assert.Contains(t, err.Error(), "local variable 'my_var' not found")
+	assert.Contains(t, err.Error(), "failed to get file info")
 }
 
-func TestSaveHclWithError(t *testing.T) {
+func TestUpdateHclFileMultiFileGlob(t *testing.T) {
+	dir := t.TempDir()
 
-	// Create a logger that writes to a buffer
-	buf := bytes.Buffer{}
-	logger := zerolog.New(&buf).With().Timestamp().Logger()
+	contents := `locals {
+  code_version = "1.1.1.1"
+}
+`
+	pathA := dir + "/a.tf"
+	pathB := dir + "/b.tf"
+	assert.NoError(t, os.WriteFile(pathA, []byte(contents), 0600))
+	assert.NoError(t, os.WriteFile(pathB, []byte(contents), 0600))
+
+	logger := log.With().Logger()
 	ctx := logger.WithContext(context.Background())
 
-	// Create an invalid file handle (nil pointer) to cause an error
-	var tempFile *os.File
+	updates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	err := updateHclFile(ctx, dir+"/*.tf", targetKindLocal, updates, updateOptions{})
+	assert.NoError(t, err)
 
-	// Create a new HCL file
-	hclFile := hclwrite.NewFile()
+	for _, path := range []string{pathA, pathB} {
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `code_version = "2.2.2.2"`)
+	}
+}
 
-	// Write some data to the HCL file
-	block := hclFile.Body().AppendNewBlock("resource", []string{"aws_s3_bucket", "my_bucket"})
-	block.Body().SetAttributeValue("bucket", cty.StringVal("my-bucket-name"))
+func TestUpdateHclFileWritesTypedLiterals(t *testing.T) {
+	file, err := os.CreateTemp("", "testhcl.tf")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
 
-	// Call the function under test, passing the logger and temporary file handle
-	err := saveHCLToFile(tempFile, ctx, hclFile)
+	_, err = file.WriteString("locals {\n  replicas = 1\n  tags     = [\"old\"]\n}\n")
+	assert.NoError(t, err)
+	file.Close()
 
-	// Check that an error was returned
-	assert.Error(t, err, "No error returned by function")
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	updates := []varAssignment{
+		{Name: "replicas", Value: cty.NumberFloatVal(3)},
+		{Name: "tags", Value: cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")})},
+	}
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(file.Name())
+	assert.NoError(t, err)
+	// numbers and lists must be written as unquoted literals, not strings
+	assert.Contains(t, string(data), "replicas = 3")
+	assert.Contains(t, string(data), `tags     = ["a", "b"]`)
+	assert.NotContains(t, string(data), `"3"`)
+}
+
+func TestUpdateHclFileMultiFileGlobAtomicity(t *testing.T) {
+	dir := t.TempDir()
+
+	good := `locals {
+  code_version = "1.1.1.1"
+}
+`
+	bad := `locals {
+  other_version = "1.1.1.1"
+}
+`
+	pathGood := dir + "/good.tf"
+	pathBad := dir + "/bad.tf"
+	assert.NoError(t, os.WriteFile(pathGood, []byte(good), 0600))
+	assert.NoError(t, os.WriteFile(pathBad, []byte(bad), 0600))
+
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	updates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	err := updateHclFile(ctx, dir+"/*.tf", targetKindLocal, updates, updateOptions{})
+	assert.ErrorContains(t, err, "failed to update target")
+
+	// the file missing the local must be left untouched
+	data, err := os.ReadFile(pathBad)
+	assert.NoError(t, err)
+	assert.Equal(t, bad, string(data))
+}
+
+func TestUpdateHclFileMultiTargetRollback(t *testing.T) {
+	file, err := os.CreateTemp("", "testhcl.tf")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	original := "locals {\n  code_version = \"1.1.1.1\"\n}\n"
+	_, err = file.WriteString(original)
+	assert.NoError(t, err)
+	file.Close()
+
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	// two updates against the same file: the first succeeds and stages an
+	// edit, the second targets a local that doesn't exist and fails. The
+	// whole file must be rolled back to its pre-run state, including the
+	// first update's staged edit.
+	updates := []varAssignment{
+		{Name: "code_version", Value: cty.StringVal("2.2.2.2")},
+		{Name: "missing_version", Value: cty.StringVal("3.3.3.3")},
+	}
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{})
+	assert.ErrorContains(t, err, "failed to update target")
+
+	data, err := os.ReadFile(file.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, original, string(data), "a failure on a later target must roll back every staged edit for the file, not just its own")
+}
+
+func TestCollectUpdatesFallsBackToEnvVars(t *testing.T) {
+	os.Setenv("INPUT_VARNAME", "code_version")
+	os.Setenv("INPUT_VALUE", "v2.55.4")
+	defer os.Unsetenv("INPUT_VARNAME")
+	defer os.Unsetenv("INPUT_VALUE")
+
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	updates, err := collectUpdates(ctx, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []varAssignment{{Name: "code_version", Value: cty.StringVal("v2.55.4")}}, updates)
+}
+
+func TestUpdateLocalPreservesFormattingGolden(t *testing.T) {
+	content := `locals {
+  # leading comment explaining the pin
+  code_version = "1.1.1.1"
+
+  # a blank line above, and a heredoc below
+  description = <<-EOT
+    multi
+    line
+    value
+  EOT
 }
+`
+	file, err := os.CreateTemp("", "testhcl.tf")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(content)
+	assert.NoError(t, err)
+	file.Close()
 
-func TestParseHclFile(t *testing.T) {
-	// create a temporary file with some HCL content
-	tmpFile, err := os.CreateTemp("", "testfile-*.hcl")
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	updates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{})
 	assert.NoError(t, err)
-	defer os.Remove(tmpFile.Name())
-	_, err = tmpFile.WriteString(`resource "aws_s3_bucket" "my_bucket" {
-  bucket = "my-bucket-name"
-}`)
+
+	data, err := os.ReadFile(file.Name())
 	assert.NoError(t, err)
-	err = tmpFile.Close()
+
+	want := strings.Replace(content, `code_version = "1.1.1.1"`, `code_version = "2.2.2.2"`, 1)
+	assert.Equal(t, want, string(data), "comments, blank lines, and heredoc formatting around the mutated attribute must be preserved byte-for-byte")
+}
+
+func TestUpdateHclFileSkipsUnchangedFileOnSecondRun(t *testing.T) {
+	file, err := os.CreateTemp("", "testhcl.tf")
 	assert.NoError(t, err)
+	defer os.Remove(file.Name())
 
-	// open the temporary file for reading
-	file, err := os.Open(tmpFile.Name())
+	_, err = file.WriteString("locals {\n  code_version = \"1.1.1.1\"\n}\n")
 	assert.NoError(t, err)
-	defer file.Close()
+	file.Close()
+
+	previousLevel := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	defer zerolog.SetGlobalLevel(previousLevel)
 
-	// Create a logger
 	buf := bytes.Buffer{}
 	logger := zerolog.New(&buf).With().Timestamp().Logger()
 	ctx := logger.WithContext(context.Background())
 
-	// call the function under test
-	hclFile, err := parseHclFile(ctx, file)
+	updates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	assert.NoError(t, updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{}))
+	assert.NotContains(t, buf.String(), "skipping file")
 
-	// assert that the function returns no error
+	// a second run with the exact same updates over the file it already
+	// produced must be recognised as a cache hit and skipped entirely
+	buf.Reset()
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{})
 	assert.NoError(t, err)
-
-	// assert that the HCL file contains the expected block
-	assert.Equal(t, 1, len(hclFile.Body().Blocks()), "File contains more than expected configuration block")
-	block := hclFile.Body().Blocks()[0]
-	assert.Equal(t, "resource", block.Type())
-	assert.Equal(t, []string{"aws_s3_bucket", "my_bucket"}, block.Labels())
-	attr := block.Body().GetAttribute("bucket")
-	assert.NotNil(t, attr)
+	assert.Contains(t, buf.String(), "skipping file unchanged since last run")
 }
 
-func TestParseHclFileInvalidFormat(t *testing.T) {
-	// create a temporary file with invalid HCL content
-	tmpFile, err := os.CreateTemp("", "testfile-*.hcl")
+func TestUpdateHclFileCacheDoesNotCollideAcrossTargetKinds(t *testing.T) {
+	file, err := os.CreateTemp("", "testhcl.tf")
 	assert.NoError(t, err)
-	defer os.Remove(tmpFile.Name())
-	_, err = tmpFile.WriteString(`resource "aws_s3_bucket" "my_bucket" {
-  bucket = "my-bucket-name"
-`)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("locals {\n  code_version = \"1.1.1.1\"\n}\n\nvariable \"code_version\" {\n  default = \"1.1.1.1\"\n}\n")
 	assert.NoError(t, err)
-	err = tmpFile.Close()
+	file.Close()
+
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	// bump the local to 2.2.2.2 first, caching that result for target kind "local"
+	localUpdates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	assert.NoError(t, updateHclFile(ctx, file.Name(), targetKindLocal, localUpdates, updateOptions{}))
+
+	// bumping the variable's default to the same name/value must not be
+	// mistaken for the already-cached local bump and skipped
+	variableUpdates := []varAssignment{{Name: "code_version", Value: cty.StringVal("2.2.2.2")}}
+	err = updateHclFile(ctx, file.Name(), targetKindVariable, variableUpdates, updateOptions{})
 	assert.NoError(t, err)
 
-	// open the temporary file for reading
-	file, err := os.Open(tmpFile.Name())
+	data, err := os.ReadFile(file.Name())
 	assert.NoError(t, err)
-	defer file.Close()
+	assert.Contains(t, string(data), `default = "2.2.2.2"`, "the variable bump must not be skipped just because a local bump of the same name/value was already cached")
+}
 
-	// call the function under test
-	hclFile, err := parseHclFile(context.Background(), file)
+func TestUpdateHclFileWithBumpCompute(t *testing.T) {
+	file, err := os.CreateTemp("", "testhcl.tf")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
 
-	// assert that the function returns an error
-	assert.Error(t, err)
-	assert.Nil(t, hclFile)
+	_, err = file.WriteString("locals {\n  code_version = \"1.2.3\"\n}\n")
+	assert.NoError(t, err)
+	file.Close()
 
-	// assert that the error message contains the expected substring
-	assert.Contains(t, err.Error(), "failed to parse file content")
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	mode, err := parseBumpMode("minor")
+	assert.NoError(t, err)
+
+	updates := []varAssignment{{Name: "code_version", Compute: makeBumpCompute(mode, "")}}
+	err = updateHclFile(ctx, file.Name(), targetKindLocal, updates, updateOptions{})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(file.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `code_version = "1.3.0"`)
 }
 
-func TestParseHclFileNilFile(t *testing.T) {
+func TestBuildBumpUpdates(t *testing.T) {
+	updates, err := buildBumpUpdates(nil, "", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, updates)
 
-	// pass in a nil file
-	var tmpFile *os.File
+	_, err = buildBumpUpdates([]string{"y.version"}, "", "", "")
+	assert.ErrorContains(t, err, "without -bump or -pin-from")
 
-	// call the function under test
-	hclFile, err := parseHclFile(context.Background(), tmpFile)
+	updates, err = buildBumpUpdates([]string{"y.version"}, "patch", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, updates, 1)
+	assert.Equal(t, "y.version", updates[0].Name)
+	assert.NotNil(t, updates[0].Compute)
 
-	// assert that the function returns an error
-	assert.Error(t, err)
-	assert.Nil(t, hclFile)
+	_, err = buildBumpUpdates([]string{"y.version"}, "bogus", "", "")
+	assert.ErrorContains(t, err, "unknown bump mode")
+}
 
-	// assert that the error message contains the expected substring
-	assert.Contains(t, err.Error(), "failed to get file info")
+func TestCollectUpdatesNoVariablesSpecified(t *testing.T) {
+	os.Unsetenv("INPUT_VARNAME")
+	os.Unsetenv("INPUT_VALUE")
+
+	logger := log.With().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	_, err := collectUpdates(ctx, "", nil)
+	assert.ErrorContains(t, err, "no variables specified")
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// registryClient queries a Terraform Registry (or a compatible mirror) for
+// the published versions of a module or provider.
+type registryClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func newRegistryClient(baseURL string) *registryClient {
+	return &registryClient{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// ModuleVersions queries GET /v1/modules/{namespace}/{name}/{provider}/versions.
+func (c *registryClient) ModuleVersions(ctx context.Context, namespace, name, provider string) ([]string, error) {
+	url := fmt.Sprintf("%s/v1/modules/%s/%s/%s/versions", c.baseURL, namespace, name, provider)
+	body, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Modules []struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		} `json:"modules"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse registry response: %w", err)
+	}
+	if len(parsed.Modules) == 0 {
+		return nil, fmt.Errorf("registry response for %s/%s/%s contained no modules", namespace, name, provider)
+	}
+
+	versions := make([]string, 0, len(parsed.Modules[0].Versions))
+	for _, v := range parsed.Modules[0].Versions {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+// ProviderVersions queries GET /v1/providers/{namespace}/{name}/versions.
+func (c *registryClient) ProviderVersions(ctx context.Context, namespace, name string) ([]string, error) {
+	url := fmt.Sprintf("%s/v1/providers/%s/%s/versions", c.baseURL, namespace, name)
+	body, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse registry response: %w", err)
+	}
+
+	versions := make([]string, 0, len(parsed.Versions))
+	for _, v := range parsed.Versions {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+func (c *registryClient) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build registry request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned %s for %s", resp.Status, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry response: %w", err)
+	}
+	return body, nil
+}
+
+// highestMatchingVersion returns the highest of versions that satisfies
+// constraintStr, a Terraform-style version constraint (e.g. "~> 5.0").
+func highestMatchingVersion(versions []string, constraintStr string) (string, error) {
+	constraints, err := version.NewConstraint(constraintStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid constraint %q: %w", constraintStr, err)
+	}
+
+	var best *version.Version
+	for _, raw := range versions {
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+		if !constraints.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best = v
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no version satisfies constraint %q", constraintStr)
+	}
+	return best.String(), nil
+}
+
+// makePinFromCompute returns a computeFunc for --pin-from: it reads the
+// target's registry source (e.g. a module's "source" attribute) and its
+// current version constraint, then resolves the highest published version
+// that still satisfies that constraint.
+func makePinFromCompute(registryBaseURL string) computeFunc {
+	client := newRegistryClient(registryBaseURL)
+
+	return func(ctx context.Context, fixer *Fixer, kind targetKind, address string, current cty.Value) (cty.Value, error) {
+		if current == cty.NilVal || current.IsNull() || current.Type() != cty.String {
+			return cty.NilVal, fmt.Errorf("current value of '%s' is not a string version constraint", address)
+		}
+
+		source, ok := resolveTargetSource(fixer, kind, address)
+		if !ok {
+			return cty.NilVal, fmt.Errorf("could not determine registry source for '%s'", address)
+		}
+
+		var versions []string
+		var err error
+		switch kind {
+		case targetKindModule:
+			parts := strings.Split(source, "/")
+			if len(parts) != 3 {
+				return cty.NilVal, fmt.Errorf("module source %q is not in <namespace>/<name>/<provider> form", source)
+			}
+			versions, err = client.ModuleVersions(ctx, parts[0], parts[1], parts[2])
+		case targetKindProvider:
+			parts := strings.Split(source, "/")
+			if len(parts) != 2 {
+				return cty.NilVal, fmt.Errorf("provider source %q is not in <namespace>/<name> form", source)
+			}
+			versions, err = client.ProviderVersions(ctx, parts[0], parts[1])
+		default:
+			return cty.NilVal, fmt.Errorf("--pin-from does not support target kind %q", kind)
+		}
+		if err != nil {
+			return cty.NilVal, err
+		}
+
+		best, err := highestMatchingVersion(versions, current.AsString())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.StringVal(best), nil
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryClientModuleVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/modules/terraform-aws-modules/vpc/aws/versions", r.URL.Path)
+		w.Write([]byte(`{"modules":[{"versions":[{"version":"3.0.0"},{"version":"3.1.0"},{"version":"4.0.0"}]}]}`))
+	}))
+	defer server.Close()
+
+	client := newRegistryClient(server.URL)
+	versions, err := client.ModuleVersions(context.Background(), "terraform-aws-modules", "vpc", "aws")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3.0.0", "3.1.0", "4.0.0"}, versions)
+}
+
+func TestRegistryClientProviderVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/providers/hashicorp/aws/versions", r.URL.Path)
+		w.Write([]byte(`{"versions":[{"version":"5.0.0"},{"version":"5.1.0"}]}`))
+	}))
+	defer server.Close()
+
+	client := newRegistryClient(server.URL)
+	versions, err := client.ProviderVersions(context.Background(), "hashicorp", "aws")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5.0.0", "5.1.0"}, versions)
+}
+
+func TestHighestMatchingVersion(t *testing.T) {
+	versions := []string{"3.0.0", "3.1.0", "4.0.0"}
+
+	got, err := highestMatchingVersion(versions, "~> 3.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "3.1.0", got)
+
+	_, err = highestMatchingVersion(versions, "~> 9.0")
+	assert.ErrorContains(t, err, "no version satisfies constraint")
+}
+
+func TestMakePinFromCompute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"modules":[{"versions":[{"version":"3.0.0"},{"version":"3.1.0"},{"version":"4.0.0"}]}]}`))
+	}))
+	defer server.Close()
+
+	content := `module "y" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 3.0"
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	current, ok := resolveTargetValue(fixer, targetKindModule, "y.version")
+	assert.True(t, ok)
+
+	compute := makePinFromCompute(server.URL)
+	value, err := compute(context.Background(), fixer, targetKindModule, "y.version", current)
+	assert.NoError(t, err)
+	assert.Equal(t, "3.1.0", value.AsString())
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-version"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// bumpMode selects which segment of a semantic version --bump increments.
+type bumpMode string
+
+const (
+	bumpModePatch bumpMode = "patch"
+	bumpModeMinor bumpMode = "minor"
+	bumpModeMajor bumpMode = "major"
+)
+
+// parseBumpMode parses the --bump flag value.
+func parseBumpMode(raw string) (bumpMode, error) {
+	switch bumpMode(raw) {
+	case bumpModePatch, bumpModeMinor, bumpModeMajor:
+		return bumpMode(raw), nil
+	default:
+		return "", fmt.Errorf("unknown bump mode %q: expected patch, minor, or major", raw)
+	}
+}
+
+// constraintPrefixRe matches an optional Terraform constraint operator
+// (~>, >=, <=, =, >, <) and the whitespace that follows it, so the operator
+// can be preserved verbatim across a bump.
+var constraintPrefixRe = regexp.MustCompile(`^(~>|>=|<=|=|>|<)?(\s*)`)
+
+// splitConstraint splits raw into a leading constraint prefix (e.g. "~> ")
+// and the bare version that follows it (e.g. "v1.2.3"). It does not validate
+// that the version is well formed; callers parse it with go-version.
+func splitConstraint(raw string) (prefix, version string, err error) {
+	loc := constraintPrefixRe.FindStringIndex(raw)
+	prefix = raw[:loc[1]]
+	version = raw[loc[1]:]
+	if version == "" {
+		return "", "", fmt.Errorf("invalid version %q: missing version after constraint operator", raw)
+	}
+	return prefix, version, nil
+}
+
+// bumpVersion increments current's major, minor, or patch segment per mode,
+// preserving any constraint operator prefix (e.g. "~>") and leading "v"
+// found in current, and appends prerelease if non-empty. current may be a
+// bare version ("1.2.3"), a "v"-prefixed version ("v1.2.3"), or a Terraform
+// version constraint ("~> 1.2"). It returns an error rather than silently
+// rewriting current if it cannot be parsed as a version.
+func bumpVersion(current string, mode bumpMode, prerelease string) (string, error) {
+	prefix, bare, err := splitConstraint(current)
+	if err != nil {
+		return "", err
+	}
+
+	vPrefix := ""
+	if bare[0] == 'v' {
+		vPrefix = "v"
+	}
+
+	v, err := version.NewVersion(bare)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q: %w", current, err)
+	}
+
+	segments := v.Segments()
+	major, minor, patch := segments[0], segments[1], segments[2]
+	switch mode {
+	case bumpModeMajor:
+		major, minor, patch = major+1, 0, 0
+	case bumpModeMinor:
+		minor, patch = minor+1, 0
+	case bumpModePatch:
+		patch++
+	default:
+		return "", fmt.Errorf("unknown bump mode %q", mode)
+	}
+
+	next := fmt.Sprintf("%s%s%d.%d.%d", prefix, vPrefix, major, minor, patch)
+	if prerelease != "" {
+		next = fmt.Sprintf("%s-%s", next, prerelease)
+	}
+	return next, nil
+}
+
+// makeBumpCompute returns a computeFunc for --bump: it ignores the fixer and
+// target kind, and simply bumps the target's current string value.
+func makeBumpCompute(mode bumpMode, prerelease string) computeFunc {
+	return func(_ context.Context, _ *Fixer, _ targetKind, address string, current cty.Value) (cty.Value, error) {
+		if current == cty.NilVal || current.IsNull() || current.Type() != cty.String {
+			return cty.NilVal, fmt.Errorf("current value of '%s' is not a string version", address)
+		}
+
+		next, err := bumpVersion(current.AsString(), mode, prerelease)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.StringVal(next), nil
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpVersion(t *testing.T) {
+	cases := []struct {
+		name    string
+		current string
+		mode    bumpMode
+		want    string
+	}{
+		{"plain patch", "1.2.3", bumpModePatch, "1.2.4"},
+		{"plain minor", "1.2.3", bumpModeMinor, "1.3.0"},
+		{"plain major", "1.2.3", bumpModeMajor, "2.0.0"},
+		{"v-prefixed", "v1.2.3", bumpModePatch, "v1.2.4"},
+		{"constraint prefix", "~> 1.2", bumpModePatch, "~> 1.2.1"},
+		{"constraint prefix minor", ">= 1.2.3", bumpModeMinor, ">= 1.3.0"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := bumpVersion(c.current, c.mode, "")
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestBumpVersionWithPrerelease(t *testing.T) {
+	got, err := bumpVersion("1.2.3", bumpModePatch, "beta.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.4-beta.1", got)
+}
+
+func TestBumpVersionInvalid(t *testing.T) {
+	_, err := bumpVersion("not-a-version", bumpModePatch, "")
+	assert.Error(t, err)
+}
+
+func TestParseBumpMode(t *testing.T) {
+	for _, raw := range []string{"patch", "minor", "major"} {
+		mode, err := parseBumpMode(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, string(mode))
+	}
+
+	_, err := parseBumpMode("bogus")
+	assert.ErrorContains(t, err, "unknown bump mode")
+}
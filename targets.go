@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// targetKind selects which kind of Terraform construct a bump targets.
+type targetKind string
+
+const (
+	targetKindLocal     targetKind = "local"
+	targetKindVariable  targetKind = "variable"
+	targetKindModule    targetKind = "module"
+	targetKindProvider  targetKind = "provider"
+	targetKindTerraform targetKind = "terraform"
+)
+
+// parseTargetKind parses the --target-kind flag value, defaulting to "local"
+// to preserve the tool's original locals-only behaviour.
+func parseTargetKind(raw string) (targetKind, error) {
+	switch targetKind(raw) {
+	case "", targetKindLocal:
+		return targetKindLocal, nil
+	case targetKindVariable, targetKindModule, targetKindProvider, targetKindTerraform:
+		return targetKind(raw), nil
+	default:
+		return "", fmt.Errorf("unknown target kind %q: expected local, variable, module, provider, or terraform", raw)
+	}
+}
+
+// updateTarget dispatches a single update to the walker for the given
+// target kind. address is interpreted per kind: a bare name for local and
+// variable, "<label>.<attribute>" for module (e.g. "y.version") and provider
+// (e.g. "aws.version"), and a bare attribute name for terraform (e.g.
+// "required_version").
+func updateTarget(ctx context.Context, fixer *Fixer, kind targetKind, address string, value cty.Value) error {
+	switch kind {
+	case targetKindLocal:
+		return updateLocal(ctx, fixer, address, value)
+	case targetKindVariable:
+		return updateVariable(ctx, fixer, address, value)
+	case targetKindModule:
+		return updateModule(ctx, fixer, address, value)
+	case targetKindProvider:
+		return updateProvider(ctx, fixer, address, value)
+	case targetKindTerraform:
+		return updateTerraformAttribute(ctx, fixer, address, value)
+	default:
+		return fmt.Errorf("unknown target kind %q", kind)
+	}
+}
+
+// updateLocal sets the value of varname within a locals block.
+func updateLocal(ctx context.Context, fixer *Fixer, varname string, value cty.Value) error {
+	found := false
+	for _, block := range fixer.File().Body().Blocks() {
+		if block.Type() == "locals" {
+			local := block.Body().GetAttribute(varname)
+			if local != nil {
+				found = true
+				sourceRange, _ := fixer.AttributeRange([]blockSpec{{Type: "locals"}}, varname)
+				fixer.SetAttributeValue(block.Body(), sourceRange, varname, value)
+				break // exit loop once variable is found and updated
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("local variable '%s' not found", varname)
+	}
+	return nil
+}
+
+// updateVariable sets the default of variable "name" { default = ... }.
+func updateVariable(ctx context.Context, fixer *Fixer, name string, value cty.Value) error {
+	block := hclwriteBlock(fixer.File().Body(), "variable", []string{name})
+	if block == nil {
+		return fmt.Errorf("variable '%s' not found", name)
+	}
+	if block.Body().GetAttribute("default") == nil {
+		return fmt.Errorf("variable '%s' has no default to bump", name)
+	}
+
+	path := []blockSpec{{Type: "variable", Labels: []string{name}}}
+	sourceRange, _ := fixer.AttributeRange(path, "default")
+	fixer.SetAttributeValue(block.Body(), sourceRange, "default", value)
+	return nil
+}
+
+// updateModule sets a version pin on module "label" { version = ... },
+// addressed as "label.version".
+func updateModule(ctx context.Context, fixer *Fixer, address string, value cty.Value) error {
+	label, attrName, err := splitAddress(address)
+	if err != nil {
+		return err
+	}
+
+	block := hclwriteBlock(fixer.File().Body(), "module", []string{label})
+	if block == nil {
+		return fmt.Errorf("module '%s' not found", label)
+	}
+	if block.Body().GetAttribute(attrName) == nil {
+		return fmt.Errorf("module '%s' has no attribute '%s'", label, attrName)
+	}
+
+	path := []blockSpec{{Type: "module", Labels: []string{label}}}
+	sourceRange, _ := fixer.AttributeRange(path, attrName)
+	fixer.SetAttributeValue(block.Body(), sourceRange, attrName, value)
+	return nil
+}
+
+// updateProvider sets a field (typically "version") within the object
+// assigned to a provider in terraform { required_providers { name = {...} } },
+// addressed as "name.field".
+func updateProvider(ctx context.Context, fixer *Fixer, address string, value cty.Value) error {
+	providerName, field, err := splitAddress(address)
+	if err != nil {
+		return err
+	}
+
+	tfBlock := hclwriteBlock(fixer.File().Body(), "terraform", nil)
+	if tfBlock == nil {
+		return fmt.Errorf("terraform block not found")
+	}
+	requiredProviders := hclwriteBlock(tfBlock.Body(), "required_providers", nil)
+	if requiredProviders == nil {
+		return fmt.Errorf("required_providers block not found")
+	}
+	if requiredProviders.Body().GetAttribute(providerName) == nil {
+		return fmt.Errorf("provider '%s' not found in required_providers", providerName)
+	}
+
+	path := []blockSpec{{Type: "terraform"}, {Type: "required_providers"}}
+	current, ok := fixer.AttributeValue(path, providerName)
+	if !ok {
+		return fmt.Errorf("provider '%s' could not be fully evaluated (it may reference a variable or contain configuration_aliases); refusing to rewrite it and risk dropping its other fields", providerName)
+	}
+	if !(current.Type().IsObjectType() || current.Type().IsMapType()) {
+		return fmt.Errorf("provider '%s' is not an object; cannot set field '%s'", providerName, field)
+	}
+	updated := setObjectField(current, field, value)
+
+	sourceRange, _ := fixer.AttributeRange(path, providerName)
+	fixer.SetAttributeValue(requiredProviders.Body(), sourceRange, providerName, updated)
+	return nil
+}
+
+// updateTerraformAttribute sets a top-level attribute of the terraform
+// block, e.g. required_version.
+func updateTerraformAttribute(ctx context.Context, fixer *Fixer, name string, value cty.Value) error {
+	block := hclwriteBlock(fixer.File().Body(), "terraform", nil)
+	if block == nil {
+		return fmt.Errorf("terraform block not found")
+	}
+	if block.Body().GetAttribute(name) == nil {
+		return fmt.Errorf("attribute '%s' not found in terraform block", name)
+	}
+
+	path := []blockSpec{{Type: "terraform"}}
+	sourceRange, _ := fixer.AttributeRange(path, name)
+	fixer.SetAttributeValue(block.Body(), sourceRange, name, value)
+	return nil
+}
+
+// resolveTargetValue reads a target's current value, for use by a
+// computeFunc (--bump, --pin-from) that needs to know what it is bumping
+// from. It returns false if the target or attribute cannot be found.
+func resolveTargetValue(fixer *Fixer, kind targetKind, address string) (cty.Value, bool) {
+	switch kind {
+	case targetKindLocal:
+		return fixer.AttributeValue([]blockSpec{{Type: "locals"}}, address)
+	case targetKindVariable:
+		return fixer.AttributeValue([]blockSpec{{Type: "variable", Labels: []string{address}}}, "default")
+	case targetKindTerraform:
+		return fixer.AttributeValue([]blockSpec{{Type: "terraform"}}, address)
+	case targetKindModule:
+		label, attrName, err := splitAddress(address)
+		if err != nil {
+			return cty.NilVal, false
+		}
+		return fixer.AttributeValue([]blockSpec{{Type: "module", Labels: []string{label}}}, attrName)
+	case targetKindProvider:
+		providerName, field, err := splitAddress(address)
+		if err != nil {
+			return cty.NilVal, false
+		}
+		return providerField(fixer, providerName, field)
+	default:
+		return cty.NilVal, false
+	}
+}
+
+// resolveTargetSource looks up the registry source coordinates (e.g.
+// "terraform-aws-modules/vpc/aws") for a module or provider target, for use
+// by --pin-from. It returns false if the target has no source attribute.
+func resolveTargetSource(fixer *Fixer, kind targetKind, address string) (string, bool) {
+	switch kind {
+	case targetKindModule:
+		label, _, err := splitAddress(address)
+		if err != nil {
+			return "", false
+		}
+		source, ok := fixer.AttributeValue([]blockSpec{{Type: "module", Labels: []string{label}}}, "source")
+		if !ok || source.IsNull() || source.Type() != cty.String {
+			return "", false
+		}
+		return source.AsString(), true
+	case targetKindProvider:
+		providerName, _, err := splitAddress(address)
+		if err != nil {
+			return "", false
+		}
+		source, ok := providerField(fixer, providerName, "source")
+		if !ok || source.IsNull() || source.Type() != cty.String {
+			return "", false
+		}
+		return source.AsString(), true
+	default:
+		return "", false
+	}
+}
+
+// providerField reads a single field (e.g. "version" or "source") out of a
+// provider's entry in terraform { required_providers { name = {...} } }.
+func providerField(fixer *Fixer, providerName, field string) (cty.Value, bool) {
+	path := []blockSpec{{Type: "terraform"}, {Type: "required_providers"}}
+	obj, ok := fixer.AttributeValue(path, providerName)
+	if !ok || obj.IsNull() || !(obj.Type().IsObjectType() || obj.Type().IsMapType()) {
+		return cty.NilVal, false
+	}
+	v, ok := obj.AsValueMap()[field]
+	return v, ok
+}
+
+// hclwriteBlock returns the first direct child block of body matching type
+// and labels, or nil if there is none.
+func hclwriteBlock(body *hclwrite.Body, blockType string, labels []string) *hclwrite.Block {
+	for _, block := range body.Blocks() {
+		if block.Type() == blockType && labelsMatch(block.Labels(), labels) {
+			return block
+		}
+	}
+	return nil
+}
+
+// splitAddress splits a "<name>.<attribute>" address, as used to target a
+// module or provider version pin.
+func splitAddress(address string) (name, attribute string, err error) {
+	idx := strings.LastIndex(address, ".")
+	if idx <= 0 || idx == len(address)-1 {
+		return "", "", fmt.Errorf("invalid address %q: expected <name>.<attribute>", address)
+	}
+	return address[:idx], address[idx+1:], nil
+}
+
+// setObjectField returns a copy of base (an object/map cty.Value, or any
+// null/invalid value) with field set to value.
+func setObjectField(base cty.Value, field string, value cty.Value) cty.Value {
+	attrs := map[string]cty.Value{}
+	if base != cty.NilVal && !base.IsNull() && (base.Type().IsObjectType() || base.Type().IsMapType()) {
+		for k, v := range base.AsValueMap() {
+			attrs[k] = v
+		}
+	}
+	attrs[field] = value
+	return cty.ObjectVal(attrs)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParseTargetKind(t *testing.T) {
+	cases := map[string]targetKind{
+		"":          targetKindLocal,
+		"local":     targetKindLocal,
+		"variable":  targetKindVariable,
+		"module":    targetKindModule,
+		"provider":  targetKindProvider,
+		"terraform": targetKindTerraform,
+	}
+	for raw, want := range cases {
+		got, err := parseTargetKind(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := parseTargetKind("bogus")
+	assert.ErrorContains(t, err, "unknown target kind")
+}
+
+func TestUpdateLocalNotFound(t *testing.T) {
+	// create a new Fixer over an empty HCL file with no locals block
+	fixer, err := NewFixer("empty.tf", nil)
+	assert.NoError(t, err)
+
+	err = updateLocal(context.Background(), fixer, "my_var", cty.StringVal("my_value"))
+	assert.ErrorContains(t, err, "local variable 'my_var' not found")
+}
+
+func TestUpdateVariable(t *testing.T) {
+	content := `variable "code_version" {
+  type    = string
+  default = "1.1.1.1"
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	err = updateVariable(context.Background(), fixer, "code_version", cty.StringVal("2.2.2.2"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(fixer.Bytes()), `default = "2.2.2.2"`)
+}
+
+func TestUpdateVariableNotFound(t *testing.T) {
+	fixer, err := NewFixer("test.tf", []byte(""))
+	assert.NoError(t, err)
+
+	err = updateVariable(context.Background(), fixer, "code_version", cty.StringVal("2.2.2.2"))
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestUpdateModule(t *testing.T) {
+	content := `module "y" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "3.0.0"
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	err = updateModule(context.Background(), fixer, "y.version", cty.StringVal("3.1.0"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(fixer.Bytes()), `version = "3.1.0"`)
+}
+
+func TestUpdateModuleInvalidAddress(t *testing.T) {
+	fixer, err := NewFixer("test.tf", []byte(""))
+	assert.NoError(t, err)
+
+	err = updateModule(context.Background(), fixer, "y", cty.StringVal("3.1.0"))
+	assert.ErrorContains(t, err, "invalid address")
+}
+
+func TestUpdateProvider(t *testing.T) {
+	content := `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	err = updateProvider(context.Background(), fixer, "aws.version", cty.StringVal("~> 5.1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(fixer.Bytes()), `version = "~> 5.1"`)
+	// the source field must survive the rewrite of the provider object
+	assert.Contains(t, string(fixer.Bytes()), `source  = "hashicorp/aws"`)
+}
+
+func TestUpdateProviderWithConfigurationAliasesErrors(t *testing.T) {
+	content := `terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version                = "~> 5.0"
+      configuration_aliases  = [aws.east, aws.west]
+    }
+  }
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+	before := string(fixer.Bytes())
+
+	err = updateProvider(context.Background(), fixer, "aws.version", cty.StringVal("~> 5.1"))
+	assert.ErrorContains(t, err, "could not be fully evaluated")
+
+	// a provider entry that can't be fully evaluated must be left untouched
+	// rather than silently rebuilt with only the bumped field
+	assert.Equal(t, before, string(fixer.Bytes()))
+}
+
+func TestUpdateProviderNotFound(t *testing.T) {
+	fixer, err := NewFixer("test.tf", []byte("terraform {\n  required_providers {}\n}\n"))
+	assert.NoError(t, err)
+
+	err = updateProvider(context.Background(), fixer, "aws.version", cty.StringVal("~> 5.1"))
+	assert.ErrorContains(t, err, "not found in required_providers")
+}
+
+func TestUpdateTerraformAttribute(t *testing.T) {
+	content := `terraform {
+  required_version = ">= 1.0"
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	err = updateTerraformAttribute(context.Background(), fixer, "required_version", cty.StringVal(">= 1.5"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(fixer.Bytes()), `required_version = ">= 1.5"`)
+}
+
+func TestUpdateTerraformAttributeNotFound(t *testing.T) {
+	fixer, err := NewFixer("test.tf", []byte(""))
+	assert.NoError(t, err)
+
+	err = updateTerraformAttribute(context.Background(), fixer, "required_version", cty.StringVal(">= 1.5"))
+	assert.ErrorContains(t, err, "terraform block not found")
+}
+
+func TestResolveTargetValue(t *testing.T) {
+	content := `module "y" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "3.0.0"
+}
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	value, ok := resolveTargetValue(fixer, targetKindModule, "y.version")
+	assert.True(t, ok)
+	assert.Equal(t, "3.0.0", value.AsString())
+
+	value, ok = resolveTargetValue(fixer, targetKindProvider, "aws.version")
+	assert.True(t, ok)
+	assert.Equal(t, "~> 5.0", value.AsString())
+
+	_, ok = resolveTargetValue(fixer, targetKindModule, "missing.version")
+	assert.False(t, ok)
+}
+
+func TestResolveTargetSource(t *testing.T) {
+	content := `module "y" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "3.0.0"
+}
+`
+	fixer, err := NewFixer("test.tf", []byte(content))
+	assert.NoError(t, err)
+
+	source, ok := resolveTargetSource(fixer, targetKindModule, "y.version")
+	assert.True(t, ok)
+	assert.Equal(t, "terraform-aws-modules/vpc/aws", source)
+
+	_, ok = resolveTargetSource(fixer, targetKindLocal, "code_version")
+	assert.False(t, ok)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// varAssignment pairs a variable name with the typed value it should be
+// bumped to. If Compute is set, Value is ignored and the new value is
+// derived per file from the target's current value instead (used by
+// --bump and --pin-from, whose result depends on what is already written).
+type varAssignment struct {
+	Name    string
+	Value   cty.Value
+	Compute computeFunc
+}
+
+// computeFunc derives a new value for address from its current value within
+// fixer, e.g. by bumping a semver version or querying a module registry.
+type computeFunc func(ctx context.Context, fixer *Fixer, kind targetKind, address string, current cty.Value) (cty.Value, error)
+
+// stringFlagList implements flag.Value so that a bare, repeatable flag (no
+// "=value" suffix, unlike -var) can be collected, e.g. -bump-target
+// y.version -bump-target aws.version.
+type stringFlagList struct {
+	values []string
+}
+
+func newStringFlagList() *stringFlagList {
+	return &stringFlagList{}
+}
+
+func (s *stringFlagList) String() string {
+	return strings.Join(s.values, ",")
+}
+
+func (s *stringFlagList) Set(raw string) error {
+	s.values = append(s.values, raw)
+	return nil
+}
+
+// varFlagList implements flag.Value so that -var can be repeated on the
+// command line, e.g. -var replicas=3 -var name=foo.
+type varFlagList struct {
+	values []varAssignment
+}
+
+func newVarFlagList() *varFlagList {
+	return &varFlagList{}
+}
+
+func (v *varFlagList) String() string {
+	parts := make([]string, len(v.values))
+	for i, a := range v.values {
+		parts[i] = fmt.Sprintf("%s=%s", a.Name, a.Value.GoString())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (v *varFlagList) Set(raw string) error {
+	assignment, err := parseVarFlag(raw)
+	if err != nil {
+		return err
+	}
+	v.values = append(v.values, assignment)
+	return nil
+}
+
+// parseVarFlag parses a single -var flag value of the form name=value.
+func parseVarFlag(raw string) (varAssignment, error) {
+	name, rawValue, ok := strings.Cut(raw, "=")
+	if !ok {
+		return varAssignment{}, fmt.Errorf("invalid -var %q: expected name=value", raw)
+	}
+
+	value, err := parseTypedValue(rawValue)
+	if err != nil {
+		return varAssignment{}, fmt.Errorf("invalid value for %q: %w", name, err)
+	}
+
+	return varAssignment{Name: name, Value: value}, nil
+}
+
+// parseTypedValue parses a raw string as a typed cty value. Values that
+// parse as JSON (booleans, numbers, lists, objects) are converted to the
+// matching cty type; anything else is treated as a plain string, so existing
+// callers passing bare strings like "v2.55.4" keep working unchanged.
+func parseTypedValue(raw string) (cty.Value, error) {
+	var generic interface{}
+	if err := json.Unmarshal([]byte(raw), &generic); err == nil {
+		return goValueToCty(generic), nil
+	}
+	return cty.StringVal(raw), nil
+}
+
+// goValueToCty converts a value decoded from JSON into the equivalent cty
+// value.
+func goValueToCty(v interface{}) cty.Value {
+	switch t := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType)
+	case bool:
+		return cty.BoolVal(t)
+	case float64:
+		return cty.NumberFloatVal(t)
+	case string:
+		return cty.StringVal(t)
+	case []interface{}:
+		if len(t) == 0 {
+			return cty.EmptyTupleVal
+		}
+		vals := make([]cty.Value, len(t))
+		for i, item := range t {
+			vals[i] = goValueToCty(item)
+		}
+		return cty.TupleVal(vals)
+	case map[string]interface{}:
+		attrs := make(map[string]cty.Value, len(t))
+		for k, item := range t {
+			attrs[k] = goValueToCty(item)
+		}
+		return cty.ObjectVal(attrs)
+	default:
+		return cty.StringVal(fmt.Sprintf("%v", t))
+	}
+}
+
+// loadVarFile reads a JSON or HCL file of name -> value overrides, as used
+// by -var-file. Values are parsed with their native types rather than
+// stringified, mirroring how Terraform's own *.tfvars files work.
+func loadVarFile(path string) (map[string]cty.Value, error) {
+	switch ext := filepath.Ext(path); ext {
+	case ".hcl", ".tf", ".tfvars":
+		return loadVarFileHCL(path)
+	default:
+		return loadVarFileJSON(path)
+	}
+}
+
+func loadVarFileJSON(path string) (map[string]cty.Value, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read var file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse var file as JSON: %w", err)
+	}
+
+	values := make(map[string]cty.Value, len(raw))
+	for name, v := range raw {
+		values[name] = goValueToCty(v)
+	}
+
+	return values, nil
+}
+
+func loadVarFileHCL(path string) (map[string]cty.Value, error) {
+	parser := hclparse.NewParser()
+	f, diags := parser.ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse var file as HCL: %s", diags)
+	}
+
+	attrs, diags := f.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to read var file attributes: %s", diags)
+	}
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to evaluate %q in var file: %s", name, diags)
+		}
+		values[name] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParseTypedValue(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      string
+		expected cty.Value
+	}{
+		{"string", "v2.55.4", cty.StringVal("v2.55.4")},
+		{"bool", "true", cty.True},
+		{"number", "3", cty.NumberFloatVal(3)},
+		{"list", `["a","b"]`, cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")})},
+		{"object", `{"a":1}`, cty.ObjectVal(map[string]cty.Value{"a": cty.NumberFloatVal(1)})},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			value, err := parseTypedValue(c.raw)
+			assert.NoError(t, err)
+			assert.True(t, c.expected.RawEquals(value), "expected %#v, got %#v", c.expected, value)
+		})
+	}
+}
+
+func TestParseVarFlag(t *testing.T) {
+	assignment, err := parseVarFlag("replicas=3")
+	assert.NoError(t, err)
+	assert.Equal(t, "replicas", assignment.Name)
+	assert.True(t, cty.NumberFloatVal(3).RawEquals(assignment.Value))
+}
+
+func TestParseVarFlagMissingEquals(t *testing.T) {
+	_, err := parseVarFlag("replicas")
+	assert.ErrorContains(t, err, "expected name=value")
+}
+
+func TestLoadVarFileJSON(t *testing.T) {
+	path := t.TempDir() + "/vars.json"
+	assert.NoError(t, os.WriteFile(path, []byte(`{"replicas": 3, "name": "foo"}`), 0600))
+
+	values, err := loadVarFile(path)
+	assert.NoError(t, err)
+	assert.True(t, cty.NumberFloatVal(3).RawEquals(values["replicas"]))
+	assert.True(t, cty.StringVal("foo").RawEquals(values["name"]))
+}
+
+func TestLoadVarFileHCL(t *testing.T) {
+	path := t.TempDir() + "/vars.tfvars"
+	assert.NoError(t, os.WriteFile(path, []byte("replicas = 3\nname = \"foo\"\n"), 0600))
+
+	values, err := loadVarFile(path)
+	assert.NoError(t, err)
+	assert.True(t, cty.NumberFloatVal(3).RawEquals(values["replicas"]))
+	assert.True(t, cty.StringVal("foo").RawEquals(values["name"]))
+}